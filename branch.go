@@ -0,0 +1,494 @@
+package trie
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MaxPrefixPerNode bounds how many runes a single Branch.Prefix may hold
+// before a long common run of runes has to be chained across several
+// Branch nodes instead of collapsing into one.
+const MaxPrefixPerNode = 10
+
+// MaxChildrenPerSparseNode is the upper bound on the number of children a
+// sparseChildList keeps before it is promoted to a denseChildList. Below
+// this threshold a linearly scanned slice is cheaper - in memory and in
+// cache behaviour - than a map.
+const MaxChildrenPerSparseNode = 8
+
+/*
+MemberInfo holds a single Trie entry together with how often it has been
+added.
+*/
+type MemberInfo struct {
+	Value string
+	Count int64
+}
+
+/*
+Branch is a node of the compressed (Patricia-style) trie. Rather than one
+Branch per rune, a Branch stores a Prefix of up to MaxPrefixPerNode runes
+that is common to everything below it, and only branches into children
+where entries actually diverge. A Branch with Count > 0 marks the end of
+an entry - the concatenation of all Prefixes from the root down to and
+including this Branch.
+*/
+type Branch struct {
+	sync.RWMutex
+	Prefix   []rune
+	Count    int64
+	children childList
+}
+
+/*
+childList abstracts over how a Branch stores its children so that nodes
+with few children can stay cheap (sparseChildList) while nodes with many
+children get faster lookups (denseChildList). Implementations return the
+childList that should replace the receiver - e.g. a sparseChildList may
+hand back a denseChildList once it grows past MaxChildrenPerSparseNode.
+*/
+type childList interface {
+	get(r rune) *Branch
+	put(r rune, b *Branch) childList
+	delete(r rune) childList
+	keys() []rune
+	len() int
+}
+
+func newChildList() childList {
+	return &sparseChildList{}
+}
+
+// sparseChildList is a linearly scanned, unsorted pair of slices. It is
+// used for nodes with few children, where scanning a handful of entries
+// beats the overhead of a map.
+type sparseChildList struct {
+	runes    []rune
+	branches []*Branch
+}
+
+func (l *sparseChildList) get(r rune) *Branch {
+	for i, rr := range l.runes {
+		if rr == r {
+			return l.branches[i]
+		}
+	}
+	return nil
+}
+
+func (l *sparseChildList) put(r rune, b *Branch) childList {
+	for i, rr := range l.runes {
+		if rr == r {
+			l.branches[i] = b
+			return l
+		}
+	}
+	l.runes = append(l.runes, r)
+	l.branches = append(l.branches, b)
+	if len(l.runes) > MaxChildrenPerSparseNode {
+		return l.toDense()
+	}
+	return l
+}
+
+func (l *sparseChildList) delete(r rune) childList {
+	for i, rr := range l.runes {
+		if rr == r {
+			l.runes = append(l.runes[:i], l.runes[i+1:]...)
+			l.branches = append(l.branches[:i], l.branches[i+1:]...)
+			break
+		}
+	}
+	return l
+}
+
+func (l *sparseChildList) keys() []rune {
+	keys := append([]rune{}, l.runes...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func (l *sparseChildList) len() int {
+	return len(l.runes)
+}
+
+func (l *sparseChildList) toDense() childList {
+	d := &denseChildList{branches: make(map[rune]*Branch, len(l.runes))}
+	for i, r := range l.runes {
+		d.branches[r] = l.branches[i]
+	}
+	return d
+}
+
+// denseChildList is a map-backed childList used once a node has grown
+// past MaxChildrenPerSparseNode children.
+type denseChildList struct {
+	branches map[rune]*Branch
+}
+
+func (l *denseChildList) get(r rune) *Branch {
+	return l.branches[r]
+}
+
+func (l *denseChildList) put(r rune, b *Branch) childList {
+	l.branches[r] = b
+	return l
+}
+
+func (l *denseChildList) delete(r rune) childList {
+	delete(l.branches, r)
+	if len(l.branches) <= MaxChildrenPerSparseNode {
+		return l.toSparse()
+	}
+	return l
+}
+
+func (l *denseChildList) keys() []rune {
+	keys := make([]rune, 0, len(l.branches))
+	for r := range l.branches {
+		keys = append(keys, r)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func (l *denseChildList) len() int {
+	return len(l.branches)
+}
+
+func (l *denseChildList) toSparse() childList {
+	s := &sparseChildList{}
+	for _, r := range l.keys() {
+		s.runes = append(s.runes, r)
+		s.branches = append(s.branches, l.branches[r])
+	}
+	return s
+}
+
+// commonPrefixLen returns how many leading runes a and b have in common.
+func commonPrefixLen(a, b []rune) int {
+	max := len(a)
+	if len(b) < max {
+		max = len(b)
+	}
+	i := 0
+	for i < max && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+/*
+add inserts rem - the part of the entry not yet accounted for by the
+path from the root down to and including this Branch - and returns the
+Branch that now marks the end of the entry.
+*/
+func (b *Branch) add(rem []rune) *Branch {
+	if b.children == nil {
+		b.children = newChildList()
+	}
+	if len(rem) == 0 {
+		b.Count++
+		return b
+	}
+
+	child := b.children.get(rem[0])
+	if child == nil {
+		chunk := rem
+		if len(chunk) > MaxPrefixPerNode {
+			chunk = chunk[:MaxPrefixPerNode]
+		}
+		newBranch := &Branch{Prefix: append([]rune{}, chunk...), children: newChildList()}
+		b.children = b.children.put(rem[0], newBranch)
+		return newBranch.add(rem[len(chunk):])
+	}
+
+	k := commonPrefixLen(child.Prefix, rem)
+	if k == len(child.Prefix) {
+		return child.add(rem[k:])
+	}
+
+	// The new key diverges from child.Prefix at position k: split child
+	// into a parent holding the shared prefix and two children - the old
+	// tail of child, and (if anything remains) the new suffix.
+	split := &Branch{Prefix: append([]rune{}, child.Prefix[:k]...), children: newChildList()}
+	child.Prefix = append([]rune{}, child.Prefix[k:]...)
+	split.children = split.children.put(child.Prefix[0], child)
+	b.children = b.children.put(rem[0], split)
+
+	remAfterSplit := rem[k:]
+	if len(remAfterSplit) == 0 {
+		split.Count++
+		return split
+	}
+	return split.add(remAfterSplit)
+}
+
+/*
+descendPrefix walks rem down from this Branch and reports whether rem is
+a valid path through the trie. When rem lands exactly on a Branch
+boundary that Branch is returned as well; if rem ends part way through a
+child's Prefix the path is still valid but there is no Branch to return.
+*/
+func (b *Branch) descendPrefix(rem []rune) (node *Branch, matched bool) {
+	if len(rem) == 0 {
+		return b, true
+	}
+	if b.children == nil {
+		return nil, false
+	}
+	child := b.children.get(rem[0])
+	if child == nil {
+		return nil, false
+	}
+	k := commonPrefixLen(child.Prefix, rem)
+	if k == len(rem) {
+		if k == len(child.Prefix) {
+			return child, true
+		}
+		return nil, true
+	}
+	if k < len(child.Prefix) {
+		return nil, false
+	}
+	return child.descendPrefix(rem[k:])
+}
+
+/*
+getBranch returns the Branch at which rem ends, or nil if rem is not a
+path that was ever added to the trie.
+*/
+func (b *Branch) getBranch(rem []rune) *Branch {
+	node, matched := b.descendPrefix(rem)
+	if !matched {
+		return nil
+	}
+	return node
+}
+
+/*
+has reports whether rem names an entry that is currently present (i.e.
+its Branch exists and its Count is greater than zero).
+*/
+func (b *Branch) has(rem []rune) bool {
+	node := b.getBranch(rem)
+	return node != nil && node.Count > 0
+}
+
+/*
+hasCount reports whether rem names a present entry and, if so, how many
+times it has been added.
+*/
+func (b *Branch) hasCount(rem []rune) (bool, int64) {
+	node := b.getBranch(rem)
+	if node == nil || node.Count == 0 {
+		return false, 0
+	}
+	return true, node.Count
+}
+
+/*
+hasPrefix reports whether any entry in the trie starts with rem.
+*/
+func (b *Branch) hasPrefix(rem []rune) bool {
+	_, matched := b.descendPrefix(rem)
+	return matched
+}
+
+/*
+hasPrefixCount reports whether any entry starts with rem and, if rem also
+happens to be a complete entry in its own right, how many times that
+entry has been added.
+*/
+func (b *Branch) hasPrefixCount(rem []rune) (bool, int64) {
+	node, matched := b.descendPrefix(rem)
+	if !matched {
+		return false, 0
+	}
+	if node == nil {
+		return true, 0
+	}
+	return true, node.Count
+}
+
+/*
+walk visits every entry at or below this Branch in ascending rune order,
+with soFar as the already-accumulated prefix of runes leading to this
+Branch. See VisitorFunc, SkipSubtree and StopWalk for how v's return
+value controls the traversal.
+*/
+func (b *Branch) walk(soFar []rune, v VisitorFunc) error {
+	if b.Count > 0 {
+		if err := v(string(soFar), b.Count); err != nil {
+			if err == SkipSubtree {
+				return nil
+			}
+			return err
+		}
+	}
+	if b.children == nil {
+		return nil
+	}
+	for _, r := range b.children.keys() {
+		child := b.children.get(r)
+		childSoFar := append(append([]rune{}, soFar...), child.Prefix...)
+		if err := child.walk(childSoFar, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+walkPrefix visits every entry below this Branch whose key starts with
+rem, the part of the prefix not yet matched; soFar is the prefix already
+accumulated on the way down to this Branch.
+*/
+func (b *Branch) walkPrefix(soFar []rune, rem []rune, v VisitorFunc) error {
+	node, base, ok := b.locatePrefix(soFar, rem)
+	if !ok {
+		return nil
+	}
+	return node.walk(base, v)
+}
+
+/*
+locatePrefix walks rem down from this Branch and, if rem names a valid
+path through the trie, returns the Branch that roots the subtree of
+everything starting with rem together with the full rune prefix (base)
+leading to it. ok is false if rem is not a valid path at all.
+*/
+func (b *Branch) locatePrefix(soFar []rune, rem []rune) (node *Branch, base []rune, ok bool) {
+	if len(rem) == 0 {
+		return b, soFar, true
+	}
+	if b.children == nil {
+		return nil, nil, false
+	}
+	child := b.children.get(rem[0])
+	if child == nil {
+		return nil, nil, false
+	}
+	k := commonPrefixLen(child.Prefix, rem)
+	childSoFar := append(append([]rune{}, soFar...), child.Prefix...)
+	if k == len(rem) {
+		return child, childSoFar, true
+	}
+	if k < len(child.Prefix) {
+		return nil, nil, false
+	}
+	return child.locatePrefix(childSoFar, rem[k:])
+}
+
+// safeKeys returns b.children.keys(), or nil if b has no children yet.
+func safeKeys(b *Branch) []rune {
+	if b.children == nil {
+		return nil
+	}
+	return b.children.keys()
+}
+
+/*
+members returns every entry at or below this Branch, with soFar as the
+already-accumulated prefix of runes leading to this Branch. It is a thin
+wrapper over walk.
+*/
+func (b *Branch) members(soFar []rune) []*MemberInfo {
+	var out []*MemberInfo
+	b.walk(soFar, func(entry string, count int64) error {
+		out = append(out, &MemberInfo{Value: entry, Count: count})
+		return nil
+	})
+	return out
+}
+
+/*
+prefixMembers returns every entry below this Branch whose key starts
+with rem, the part of the prefix not yet matched; soFar is the prefix
+already accumulated on the way down to this Branch. It is a thin wrapper
+over walkPrefix.
+*/
+func (b *Branch) prefixMembers(soFar []rune, rem []rune) []*MemberInfo {
+	var out []*MemberInfo
+	b.walkPrefix(soFar, rem, func(entry string, count int64) error {
+		out = append(out, &MemberInfo{Value: entry, Count: count})
+		return nil
+	})
+	return out
+}
+
+/*
+delete removes one occurrence of rem from the trie, merging nodes back
+together where that leaves a Branch with a single non-terminal child.
+Returns whether rem was present.
+*/
+func (b *Branch) delete(rem []rune) bool {
+	if len(rem) == 0 {
+		if b.Count == 0 {
+			return false
+		}
+		b.Count--
+		return true
+	}
+	if b.children == nil {
+		return false
+	}
+	child := b.children.get(rem[0])
+	if child == nil {
+		return false
+	}
+	k := commonPrefixLen(child.Prefix, rem)
+	if k < len(child.Prefix) {
+		return false
+	}
+	existed := child.delete(rem[k:])
+	if existed {
+		b.collapse(rem[0], child)
+	}
+	return existed
+}
+
+// collapse removes or merges child (stored under r in b.children) once a
+// delete may have left it with no reason to exist as a separate node.
+func (b *Branch) collapse(r rune, child *Branch) {
+	if child.Count != 0 {
+		return
+	}
+	switch child.children.len() {
+	case 0:
+		b.children = b.children.delete(r)
+	case 1:
+		gcRune := child.children.keys()[0]
+		gc := child.children.get(gcRune)
+		merged := append(append([]rune{}, child.Prefix...), gc.Prefix...)
+		if len(merged) <= MaxPrefixPerNode {
+			gc.Prefix = merged
+			b.children = b.children.put(r, gc)
+		}
+	}
+}
+
+/*
+Dump returns a human-readable, indented representation of the subtree
+rooted at this Branch, for debugging.
+*/
+func (b *Branch) Dump(depth int) string {
+	var sb strings.Builder
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(&sb, "%s%q (count=%d)\n", indent, string(b.Prefix), b.Count)
+	if b.children != nil {
+		for _, r := range b.children.keys() {
+			sb.WriteString(b.children.get(r).Dump(depth + 1))
+		}
+	}
+	return sb.String()
+}
+
+/*
+PrintDump writes Dump(0) to stdout.
+*/
+func (b *Branch) PrintDump() {
+	fmt.Print(b.Dump(0))
+}
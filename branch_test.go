@@ -0,0 +1,92 @@
+package trie
+
+import "testing"
+
+func TestAddHasDeleteAgainstReference(t *testing.T) {
+	entries := []string{
+		"a", "ab", "abc", "abd", "abcdef", "abcdefghijklmnop",
+		"b", "ba", "bab",
+		"中国", "中国人民", "中国人民共和国万岁", "中国无敌", "中美友好",
+	}
+
+	tr := NewTrie()
+	ref := map[string]int64{}
+	for _, e := range entries {
+		tr.Add(e)
+		ref[e]++
+	}
+	// add one entry twice to exercise Count beyond 1
+	tr.Add("abc")
+	ref["abc"]++
+
+	for e, count := range ref {
+		if ok, c := tr.HasCount(e); !ok || c != count {
+			t.Errorf("HasCount(%q) = %v, %d; want true, %d", e, ok, c, count)
+		}
+	}
+
+	if tr.Has("abcde") {
+		t.Errorf("Has(%q) = true, want false: never added, only a prefix of abcdef...", "abcde")
+	}
+	if !tr.HasPrefix("abcde") {
+		t.Errorf("HasPrefix(%q) = false, want true: abcdef... is an entry", "abcde")
+	}
+	if tr.HasPrefix("xyz") {
+		t.Errorf("HasPrefix(%q) = true, want false", "xyz")
+	}
+
+	for e, count := range ref {
+		for i := int64(0); i < count; i++ {
+			if !tr.Delete(e) {
+				t.Fatalf("Delete(%q) = false, want true", e)
+			}
+		}
+	}
+	for e := range ref {
+		if tr.Has(e) {
+			t.Errorf("Has(%q) = true after deleting all occurrences", e)
+		}
+	}
+	if members := tr.Members(); len(members) != 0 {
+		t.Errorf("Members() = %v, want empty trie", members)
+	}
+}
+
+func TestChildListPromotionAndDemotion(t *testing.T) {
+	tr := NewTrie()
+	var keys []rune
+	for r := rune('a'); r < rune('a')+MaxChildrenPerSparseNode+3; r++ {
+		keys = append(keys, r)
+		tr.Add(string(r))
+	}
+	if _, ok := tr.Root.children.(*denseChildList); !ok {
+		t.Fatalf("root children = %T, want *denseChildList after adding %d children", tr.Root.children, len(keys))
+	}
+
+	for _, r := range keys[:len(keys)-2] {
+		if !tr.Delete(string(r)) {
+			t.Fatalf("Delete(%q) = false, want true", string(r))
+		}
+	}
+	if _, ok := tr.Root.children.(*sparseChildList); !ok {
+		t.Fatalf("root children = %T, want *sparseChildList after dropping back to 2 children", tr.Root.children)
+	}
+}
+
+func TestAddSplitsDivergingPrefix(t *testing.T) {
+	tr := NewTrie()
+	tr.Add("中国人民共和国万岁") // 9 runes, fits in a single compressed node's Prefix
+	tr.Add("中国无敌")         // diverges from the above inside a compressed node's Prefix
+
+	for _, e := range []string{"中国人民共和国万岁", "中国无敌"} {
+		if !tr.Has(e) {
+			t.Errorf("Has(%q) = false, want true", e)
+		}
+	}
+	if tr.Has("中国") {
+		t.Errorf("Has(%q) = true, want false: only a shared, non-terminal prefix", "中国")
+	}
+	if !tr.HasPrefix("中国") {
+		t.Errorf("HasPrefix(%q) = false, want true", "中国")
+	}
+}
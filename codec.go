@@ -0,0 +1,129 @@
+package trie
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+/*
+Encode writes the Trie's branches directly to w in a single depth-first
+pass: for each branch, varint(number of children), varint(count) (0 if
+the branch is not terminal), then for every child, varint(the rune it is
+keyed under), varint(how many further runes its own Prefix holds) and
+those runes, followed by the child's encoding. Unlike DumpToFile's
+previous gob-of-a-member-slice format, shared prefixes are only written
+once and Decode can rebuild the tree without re-inserting every entry.
+*/
+func (t *Trie) Encode(w io.Writer) error {
+	t.Root.RLock()
+	defer t.Root.RUnlock()
+	return encodeBranch(w, t.Root)
+}
+
+/*
+Decode reads a Trie previously written by Encode.
+*/
+func Decode(r io.Reader) (*Trie, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = newByteReader(r)
+	}
+	root, err := decodeBranch(br)
+	if err != nil {
+		return nil, err
+	}
+	return &Trie{Root: root}, nil
+}
+
+func encodeBranch(w io.Writer, b *Branch) error {
+	keys := safeKeys(b)
+	if err := writeUvarint(w, uint64(len(keys))); err != nil {
+		return err
+	}
+	if err := writeUvarint(w, uint64(b.Count)); err != nil {
+		return err
+	}
+	for _, r := range keys {
+		child := b.children.get(r)
+		if err := writeUvarint(w, uint64(r)); err != nil {
+			return err
+		}
+		rest := child.Prefix[1:]
+		if err := writeUvarint(w, uint64(len(rest))); err != nil {
+			return err
+		}
+		for _, rr := range rest {
+			if err := writeUvarint(w, uint64(rr)); err != nil {
+				return err
+			}
+		}
+		if err := encodeBranch(w, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeBranch(br io.ByteReader) (*Branch, error) {
+	numChildren, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Branch{Count: int64(count), children: newChildList()}
+	for n := uint64(0); n < numChildren; n++ {
+		keyRune, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		restLen, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		prefix := make([]rune, 0, restLen+1)
+		prefix = append(prefix, rune(keyRune))
+		for j := uint64(0); j < restLen; j++ {
+			rr, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, err
+			}
+			prefix = append(prefix, rune(rr))
+		}
+
+		child, err := decodeBranch(br)
+		if err != nil {
+			return nil, err
+		}
+		child.Prefix = prefix
+		b.children = b.children.put(rune(keyRune), child)
+	}
+	return b, nil
+}
+
+func writeUvarint(w io.Writer, v uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+// byteReader adapts an io.Reader with no ReadByte method to io.ByteReader
+// by reading one byte at a time. Callers that want buffering should pass
+// a *bufio.Reader to Decode instead.
+type byteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func newByteReader(r io.Reader) *byteReader {
+	return &byteReader{r: r}
+}
+
+func (b *byteReader) ReadByte() (byte, error) {
+	_, err := io.ReadFull(b.r, b.buf[:])
+	return b.buf[0], err
+}
@@ -0,0 +1,64 @@
+package trie
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	tr := NewTrie()
+	for _, e := range []string{"abc", "abd", "abcdef", "xyz", "中国人民", "中国无敌"} {
+		tr.Add(e)
+	}
+	tr.Add("abc") // exercise a Count greater than 1
+
+	var buf bytes.Buffer
+	if err := tr.Encode(&buf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	decoded, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !sameMembers(tr.Members(), decoded.Members()) {
+		t.Fatalf("Decode() members = %v, want %v", decoded.Members(), tr.Members())
+	}
+}
+
+func TestDumpToFileAndLoadFromFile(t *testing.T) {
+	tr := NewTrie()
+	for _, e := range []string{"foo", "foobar", "bar"} {
+		tr.Add(e)
+	}
+
+	fname := filepath.Join(t.TempDir(), "dump.bin")
+	if err := tr.DumpToFile(fname); err != nil {
+		t.Fatalf("DumpToFile: %v", err)
+	}
+
+	loaded, err := LoadFromFile(fname)
+	if err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if !sameMembers(tr.Members(), loaded.Members()) {
+		t.Fatalf("LoadFromFile() members = %v, want %v", loaded.Members(), tr.Members())
+	}
+}
+
+func sameMembers(a, b []*MemberInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	index := make(map[string]int64, len(a))
+	for _, mi := range a {
+		index[mi.Value] = mi.Count
+	}
+	for _, mi := range b {
+		if index[mi.Value] != mi.Count {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,260 @@
+package trie
+
+import "sort"
+
+/*
+TrieIterator walks the entries of a Trie one at a time, in ascending
+rune order, without recursion or a callback. It is a better fit than
+MembersList/PrefixMembersList for paginated or early-exit searches such
+as autocomplete, where materializing every match up front is wasteful.
+
+Create one with Trie.NewIterator or Trie.NewPrefixIterator, then call
+Next in a loop:
+
+	it := t.NewIterator()
+	for it.Next() {
+		fmt.Println(it.Key(), it.Count())
+	}
+
+Like Walk, an iterator needs a stable trie to run against: the
+constructor takes the Trie's RLock and holds it until the iterator is
+exhausted (Next returns false), so that Add/Delete on the same Trie
+cannot run concurrently with it. A caller that stops calling Next
+before it returns false - e.g. after finding the first match - must
+call Close to release that lock; otherwise every later Add/Delete on
+the same Trie blocks forever:
+
+	it := t.NewIterator()
+	defer it.Close()
+	for it.Next() {
+		if found(it.Key()) {
+			return it.Key()
+		}
+	}
+*/
+type TrieIterator struct {
+	root      *Branch
+	locked    bool
+	base      *Branch
+	baseSoFar []rune
+	stack     []iterFrame
+	key       string
+	count     int64
+	err       error
+}
+
+type iterFrame struct {
+	branch      *Branch
+	soFar       []rune
+	keys        []rune
+	cursor      int
+	selfEmitted bool
+}
+
+/*
+NewIterator returns a TrieIterator over every entry in the Trie.
+*/
+func (t *Trie) NewIterator() *TrieIterator {
+	it := &TrieIterator{root: t.Root, base: t.Root}
+	it.lock()
+	it.stack = []iterFrame{{branch: t.Root, keys: safeKeys(t.Root)}}
+	return it
+}
+
+/*
+NewPrefixIterator returns a TrieIterator over every entry in the Trie
+that starts with prefix.
+*/
+func (t *Trie) NewPrefixIterator(prefix string) *TrieIterator {
+	it := &TrieIterator{root: t.Root}
+	it.lock()
+	node, base, ok := t.Root.locatePrefix(nil, []rune(prefix))
+	if !ok {
+		it.release()
+		return it
+	}
+	it.base = node
+	it.baseSoFar = base
+	it.stack = []iterFrame{{branch: node, soFar: base, keys: safeKeys(node)}}
+	return it
+}
+
+func (it *TrieIterator) lock() {
+	if !it.locked {
+		it.root.RLock()
+		it.locked = true
+	}
+}
+
+func (it *TrieIterator) release() {
+	if it.locked {
+		it.root.RUnlock()
+		it.locked = false
+	}
+}
+
+/*
+Next advances the iterator to the next entry and reports whether one was
+found. Key and Count describe that entry until Next is called again.
+*/
+func (it *TrieIterator) Next() bool {
+	for len(it.stack) > 0 {
+		top := &it.stack[len(it.stack)-1]
+		if !top.selfEmitted {
+			top.selfEmitted = true
+			if top.branch.Count > 0 {
+				it.key = string(top.soFar)
+				it.count = top.branch.Count
+				return true
+			}
+		}
+		if top.cursor >= len(top.keys) {
+			it.stack = it.stack[:len(it.stack)-1]
+			continue
+		}
+		r := top.keys[top.cursor]
+		top.cursor++
+		child := top.branch.children.get(r)
+		childSoFar := append(append([]rune{}, top.soFar...), child.Prefix...)
+		it.stack = append(it.stack, iterFrame{branch: child, soFar: childSoFar, keys: safeKeys(child)})
+	}
+	it.release()
+	return false
+}
+
+/*
+Key returns the entry Next most recently advanced to.
+*/
+func (it *TrieIterator) Key() string {
+	return it.key
+}
+
+/*
+Count returns how often the entry Next most recently advanced to has
+been added.
+*/
+func (it *TrieIterator) Count() int64 {
+	return it.count
+}
+
+/*
+Err returns the first error encountered by the iterator, if any.
+*/
+func (it *TrieIterator) Err() error {
+	return it.err
+}
+
+/*
+Close releases the Trie's RLock this iterator has been holding since it
+was created or last Seek. It is safe to call Close more than once, and
+safe (a no-op) to call after Next has already returned false. Callers
+that may stop iterating before Next returns false - any early exit -
+must call Close, typically via defer, to avoid leaking the lock.
+*/
+func (it *TrieIterator) Close() {
+	it.release()
+}
+
+/*
+Seek repositions the iterator so that a following call to Next yields
+the smallest entry greater than or equal to key, and reports whether
+such an entry exists. It descends the trie following key's runes,
+pushing onto the stack the frames whose as-yet-unvisited siblings are
+already known to compare greater than key, so that Next resumes exactly
+where Seek left off.
+
+For a prefix iterator, key is interpreted relative to the whole trie,
+not the prefix subtree: if key does not itself extend the iterator's
+prefix, every entry in the subtree (which all do) compares either
+uniformly greater than key - in which case Seek lands on the first entry
+in the subtree - or uniformly less - in which case no entry qualifies.
+*/
+func (it *TrieIterator) Seek(key string) bool {
+	if it.base == nil {
+		return false
+	}
+	it.lock()
+	it.stack = it.stack[:0]
+	it.err = nil
+
+	keyRunes := []rune(key)
+	switch {
+	case hasRunePrefix(keyRunes, it.baseSoFar):
+		it.seek(it.base, it.baseSoFar, keyRunes[len(it.baseSoFar):])
+	case compareRunes(keyRunes, it.baseSoFar) <= 0:
+		// key sorts at or before the subtree's own prefix, so every entry
+		// in the subtree qualifies - start from the very first one.
+		it.seek(it.base, it.baseSoFar, nil)
+	}
+	// else key sorts strictly after the subtree's prefix without
+	// extending it, so every entry in the subtree sorts before key and
+	// none qualify; leave the stack empty.
+	return it.Next()
+}
+
+// hasRunePrefix reports whether s begins with prefix.
+func hasRunePrefix(s, prefix []rune) bool {
+	return len(s) >= len(prefix) && commonPrefixLen(s, prefix) == len(prefix)
+}
+
+// compareRunes returns -1, 0 or 1 as a compares less than, equal to, or
+// greater than b, the same way bytes.Compare does for byte slices.
+func compareRunes(a, b []rune) int {
+	k := commonPrefixLen(a, b)
+	switch {
+	case k == len(a) && k == len(b):
+		return 0
+	case k == len(a):
+		return -1
+	case k == len(b):
+		return 1
+	case a[k] < b[k]:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func (it *TrieIterator) seek(branch *Branch, soFar []rune, rem []rune) {
+	keys := safeKeys(branch)
+	frame := iterFrame{branch: branch, soFar: soFar, keys: keys}
+
+	if len(rem) == 0 {
+		it.stack = append(it.stack, frame)
+		return
+	}
+
+	r := rem[0]
+	idx := sort.Search(len(keys), func(i int) bool { return keys[i] >= r })
+	frame.selfEmitted = true // any terminal here is shorter than key, so it sorts before it
+
+	if idx >= len(keys) || keys[idx] != r {
+		// No child shares key's next rune: every remaining sibling from
+		// idx on (if any) already compares greater than key.
+		frame.cursor = idx
+		it.stack = append(it.stack, frame)
+		return
+	}
+
+	frame.cursor = idx + 1
+	it.stack = append(it.stack, frame)
+
+	child := branch.children.get(r)
+	k := commonPrefixLen(child.Prefix, rem)
+	childSoFar := append(append([]rune{}, soFar...), child.Prefix...)
+
+	switch {
+	case k == len(rem) && k == len(child.Prefix):
+		it.seek(child, childSoFar, nil)
+	case k == len(rem):
+		// rem ends inside child.Prefix: every entry under child is > key.
+		it.stack = append(it.stack, iterFrame{branch: child, soFar: childSoFar, keys: safeKeys(child)})
+	case k < len(child.Prefix):
+		if child.Prefix[k] > rem[k] {
+			it.stack = append(it.stack, iterFrame{branch: child, soFar: childSoFar, keys: safeKeys(child)})
+		}
+		// else child.Prefix[k] < rem[k]: everything under child is < key, skip it.
+	default:
+		it.seek(child, childSoFar, rem[k:])
+	}
+}
@@ -0,0 +1,105 @@
+package trie
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrieIteratorOrder(t *testing.T) {
+	tr := NewTrie()
+	for _, e := range []string{"banana", "apple", "band", "ant", "bandana"} {
+		tr.Add(e)
+	}
+
+	var got []string
+	it := tr.NewIterator()
+	for it.Next() {
+		got = append(got, it.Key())
+	}
+
+	want := []string{"ant", "apple", "banana", "band", "bandana"}
+	if !equalStrings(got, want) {
+		t.Fatalf("NewIterator order = %v, want %v", got, want)
+	}
+}
+
+func TestTrieIteratorPrefixSeek(t *testing.T) {
+	tr := NewTrie()
+	for _, e := range []string{"abc", "abd"} {
+		tr.Add(e)
+	}
+
+	if it := tr.NewPrefixIterator("ab"); !it.Seek("abc") || it.Key() != "abc" {
+		t.Fatalf("Seek(%q) under prefix %q landed on %q, want %q", "abc", "ab", it.Key(), "abc")
+	}
+
+	// Regression: "ab" is a prefix of both "abc" and "abd", both of which
+	// sort before "ac" ('b' < 'c' at the second rune) even though "ac"
+	// does not itself extend the prefix "ab". Seek must compare the key
+	// against the subtree's own prefix rather than feed the untrimmed key
+	// into the subtree-relative search.
+	it := tr.NewPrefixIterator("ab")
+	if it.Seek("ac") {
+		t.Fatalf("Seek(%q) under prefix %q = true (key %q), want false: every entry under %q sorts before %q",
+			"ac", "ab", it.Key(), "ab", "ac")
+	}
+
+	it = tr.NewPrefixIterator("ab")
+	if !it.Seek("a") || it.Key() != "abc" {
+		t.Fatalf("Seek(%q) = %q, want the subtree's first entry %q", "a", it.Key(), "abc")
+	}
+}
+
+func TestTrieIteratorEmptyPrefix(t *testing.T) {
+	tr := NewTrie()
+	tr.Add("abc")
+
+	it := tr.NewPrefixIterator("xyz")
+	if it.Next() {
+		t.Fatalf("Next() on a non-matching prefix iterator = true, want false")
+	}
+	if it.Seek("anything") {
+		t.Fatalf("Seek() on a non-matching prefix iterator = true, want false")
+	}
+}
+
+func TestTrieIteratorCloseReleasesLockOnEarlyExit(t *testing.T) {
+	tr := NewTrie()
+	for _, e := range []string{"ant", "apple", "banana"} {
+		tr.Add(e)
+	}
+
+	it := tr.NewIterator()
+	if !it.Next() {
+		t.Fatal("Next() = false, want true")
+	}
+	// Stop iterating before Next returns false - Close must release the
+	// RLock it took, or the Add below deadlocks forever.
+	it.Close()
+
+	done := make(chan struct{})
+	go func() {
+		tr.Add("cherry")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Add blocked after an early-exited iterator was Close()d: RLock leaked")
+	}
+
+	it.Close() // Close must stay safe to call more than once
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
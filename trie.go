@@ -2,14 +2,9 @@ package trie
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/gob"
 	"errors"
 	"fmt"
-	"io"
-	"log"
 	"os"
-	"time"
 )
 
 type Trie struct {
@@ -22,7 +17,7 @@ NewTrie returns the pointer to a new Trie with an initialized root Branch
 func NewTrie() *Trie {
 	t := &Trie{
 		Root: &Branch{
-			Branches: make(map[rune]*Branch),
+			children: newChildList(),
 		},
 	}
 	return t
@@ -129,6 +124,46 @@ func (t *Trie) PrefixMembersList(prefix string) (members []string) {
 	return
 }
 
+/*
+VisitorFunc is called for every entry found during a Walk or WalkPrefix,
+with the entry's value and how often it was added. Returning SkipSubtree
+stops the walk from descending into the current entry's children while
+letting it continue elsewhere; returning StopWalk unwinds the whole walk
+cleanly, so Walk/WalkPrefix return nil; any other non-nil error aborts
+the walk and is returned as-is.
+*/
+type VisitorFunc func(entry string, count int64) error
+
+var SkipSubtree = errors.New("skip subtree")
+var StopWalk = errors.New("stop walk")
+
+/*
+Walk calls v for every entry in the Trie, in ascending rune order.
+*/
+func (t *Trie) Walk(v VisitorFunc) error {
+	t.Root.RLock()
+	defer t.Root.RUnlock()
+	err := t.Root.walk([]rune{}, v)
+	if err == StopWalk {
+		return nil
+	}
+	return err
+}
+
+/*
+WalkPrefix calls v for every entry in the Trie that starts with prefix,
+in ascending rune order.
+*/
+func (t *Trie) WalkPrefix(prefix string, v VisitorFunc) error {
+	t.Root.RLock()
+	defer t.Root.RUnlock()
+	err := t.Root.walkPrefix([]rune{}, []rune(prefix), v)
+	if err == StopWalk {
+		return nil
+	}
+	return err
+}
+
 /*
 Dump returns a string representation of the `Trie`
 */
@@ -143,70 +178,38 @@ func (t *Trie) PrintDump() {
 }
 
 /*
-DumpToFile dumps all values into a slice of strings and writes that to a file
-using encoding/gob.
-
-The Trie itself can currently not be encoded directly because gob does not
-directly support structs with a sync.Mutex on them.
+DumpToFile writes the Trie to fname using Encode, the structural binary
+codec. It is a thin os.Create wrapper around Encode.
 */
 func (t *Trie) DumpToFile(fname string) (err error) {
-	t.Root.Lock()
-	entries := t.Members()
-	t.Root.Unlock()
-
-	buf := new(bytes.Buffer)
-	enc := gob.NewEncoder(buf)
-	if err = enc.Encode(entries); err != nil {
-		err = errors.New(fmt.Sprintf("Could encode Trie entries for dump file: %v", err))
-		return
-	}
-
 	f, err := os.Create(fname)
 	if err != nil {
-		err = errors.New(fmt.Sprintf("Could not save dump file: %v", err))
-		return
+		return fmt.Errorf("could not create dump file: %v", err)
 	}
 	defer f.Close()
 
 	w := bufio.NewWriter(f)
-	_, err = w.Write(buf.Bytes())
-	if err != nil {
-		err = errors.New(fmt.Sprintf("Error writing to dump file: %v", err))
-		return
+	if err = t.Encode(w); err != nil {
+		return fmt.Errorf("could not encode trie to dump file: %v", err)
 	}
-	// log.Printf("wrote %d bytes to dumpfile %s\n", bl, fname)
-	w.Flush()
-	return
+	return w.Flush()
 }
 
 /*
-LoadFromFile loads a plain wordlist from a txt file and creates a new Trie
-by Add()ing all of them.
+LoadFromFile reads a Trie back from a file written by DumpToFile, using
+Decode. It is a thin os.Open wrapper around Decode.
 */
-func (t *Trie) LoadFromFile(fname string) (tr *Trie, err error) {
-	tr = NewTrie()
-	startTime := time.Now()
-
-    f, err := os.Open(fname)
-    if err != nil {
-        panic(err)
-    }
-    defer f.Close()
-
-    rd := bufio.NewReader(f)
-    count :=0
-    for {
-        line, err := rd.ReadString('\n')
-        if err != nil || io.EOF == err {
-            break
-        }
-        tr.Add(line)
-        count++
-    }
-
-
-	log.Printf("adding %d words to index took: %v\n", count, time.Since(startTime))
+func LoadFromFile(fname string) (*Trie, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return nil, fmt.Errorf("could not open dump file: %v", err)
+	}
+	defer f.Close()
 
-	return
+	t, err := Decode(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode dump file: %v", err)
+	}
+	return t, nil
 }
 
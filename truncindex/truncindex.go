@@ -0,0 +1,132 @@
+/*
+Package truncindex implements a Docker-style short-ID lookup on top of a
+trie.Trie: given only a unique prefix of a longer ID, Get resolves it to
+the single full ID it identifies, or reports that the prefix is unknown
+or ambiguous.
+*/
+package truncindex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	trie "github.com/zhaokunyao/trie"
+)
+
+// separator is appended to every id before it is stored in the trie, so
+// that an id which happens to be a textual prefix of another (e.g.
+// "abc" and "abcdef") still ends at its own, distinct terminal node.
+const separator = '\x00'
+
+var (
+	// ErrNotExist is returned by Get when no id starts with the given
+	// prefix.
+	ErrNotExist = errors.New("truncindex: id not found")
+	// ErrAmbiguousPrefix is returned by Get when more than one id
+	// starts with the given prefix.
+	ErrAmbiguousPrefix = errors.New("truncindex: ambiguous prefix")
+)
+
+/*
+TruncIndex resolves unique prefixes of a set of string ids to their full
+id, backed by a trie.Trie for storage.
+*/
+type TruncIndex struct {
+	t  *trie.Trie
+	mu sync.RWMutex
+}
+
+/*
+NewTruncIndex returns a TruncIndex seeded with ids. Any id that is
+invalid (empty, containing the reserved separator, or a duplicate) is
+silently skipped - use Add directly if that needs to be reported.
+*/
+func NewTruncIndex(ids []string) *TruncIndex {
+	idx := &TruncIndex{t: trie.NewTrie()}
+	for _, id := range ids {
+		idx.Add(id)
+	}
+	return idx
+}
+
+func encode(id string) string {
+	return id + string(separator)
+}
+
+func decode(entry string) string {
+	return strings.TrimSuffix(entry, string(separator))
+}
+
+/*
+Add registers id with the index. It errors if id is empty, contains the
+reserved separator byte, or is already present.
+*/
+func (i *TruncIndex) Add(id string) error {
+	if id == "" {
+		return errors.New("truncindex: empty id")
+	}
+	if strings.ContainsRune(id, separator) {
+		return fmt.Errorf("truncindex: id %q contains the reserved separator", id)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.t.Has(encode(id)) {
+		return fmt.Errorf("truncindex: id already exists: %s", id)
+	}
+	i.t.Add(encode(id))
+	return nil
+}
+
+/*
+Delete removes id from the index. It errors if id is not present.
+*/
+func (i *TruncIndex) Delete(id string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if !i.t.Delete(encode(id)) {
+		return fmt.Errorf("truncindex: id does not exist: %s", id)
+	}
+	return nil
+}
+
+/*
+Get resolves prefix to the single full id it identifies. It returns
+ErrNotExist if no id starts with prefix, or ErrAmbiguousPrefix if more
+than one does. An empty prefix always yields ErrNotExist.
+*/
+func (i *TruncIndex) Get(prefix string) (string, error) {
+	if prefix == "" {
+		return "", ErrNotExist
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	it := i.t.NewPrefixIterator(prefix)
+	defer it.Close()
+
+	if !it.Next() {
+		return "", ErrNotExist
+	}
+	id := it.Key()
+	if it.Next() {
+		return "", ErrAmbiguousPrefix
+	}
+	return decode(id), nil
+}
+
+/*
+Iterate calls fn once for every id currently in the index, in ascending
+order.
+*/
+func (i *TruncIndex) Iterate(fn func(id string)) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	i.t.Walk(func(entry string, count int64) error {
+		fn(decode(entry))
+		return nil
+	})
+}
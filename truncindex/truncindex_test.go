@@ -0,0 +1,94 @@
+package truncindex
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetResolvesUniquePrefixes(t *testing.T) {
+	idx := NewTruncIndex([]string{"abcdef", "abcxyz", "zzz"})
+
+	if _, err := idx.Get("ab"); err != ErrAmbiguousPrefix {
+		t.Fatalf("Get(%q) = %v, want ErrAmbiguousPrefix", "ab", err)
+	}
+	if got, err := idx.Get("abcd"); err != nil || got != "abcdef" {
+		t.Fatalf("Get(%q) = %q, %v; want %q, nil", "abcd", got, err, "abcdef")
+	}
+	if got, err := idx.Get("z"); err != nil || got != "zzz" {
+		t.Fatalf("Get(%q) = %q, %v; want %q, nil", "z", got, err, "zzz")
+	}
+	if _, err := idx.Get("q"); err != ErrNotExist {
+		t.Fatalf("Get(%q) = %v, want ErrNotExist", "q", err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	idx := NewTruncIndex([]string{"zzz"})
+
+	if err := idx.Delete("zzz"); err != nil {
+		t.Fatalf("Delete(%q): %v", "zzz", err)
+	}
+	if _, err := idx.Get("z"); err != ErrNotExist {
+		t.Fatalf("Get(%q) after delete = %v, want ErrNotExist", "z", err)
+	}
+	if err := idx.Delete("zzz"); err == nil {
+		t.Fatalf("Delete(%q) a second time should error", "zzz")
+	}
+}
+
+func TestAddRejectsInvalidIds(t *testing.T) {
+	idx := NewTruncIndex(nil)
+
+	if err := idx.Add(""); err == nil {
+		t.Fatal("Add(\"\") should error")
+	}
+	if err := idx.Add("a\x00b"); err == nil {
+		t.Fatal("Add of an id containing the reserved separator should error")
+	}
+	if err := idx.Add("dup"); err != nil {
+		t.Fatalf("Add(%q): %v", "dup", err)
+	}
+	if err := idx.Add("dup"); err == nil {
+		t.Fatal("Add of a duplicate id should error")
+	}
+}
+
+func TestGetOnAmbiguousPrefixDoesNotLeakTheUnderlyingLock(t *testing.T) {
+	idx := NewTruncIndex([]string{"abcdef", "abcxyz"})
+
+	if _, err := idx.Get("ab"); err != ErrAmbiguousPrefix {
+		t.Fatalf("Get(%q) = %v, want ErrAmbiguousPrefix", "ab", err)
+	}
+
+	// Get's ambiguous branch must not leave the underlying trie's RLock
+	// held, or this Add deadlocks forever.
+	done := make(chan struct{})
+	go func() {
+		idx.Add("zzz")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Add blocked after Get returned ErrAmbiguousPrefix: underlying RLock leaked")
+	}
+}
+
+func TestIterate(t *testing.T) {
+	ids := []string{"one", "two", "three"}
+	idx := NewTruncIndex(ids)
+
+	seen := map[string]bool{}
+	idx.Iterate(func(id string) {
+		seen[id] = true
+	})
+	for _, id := range ids {
+		if !seen[id] {
+			t.Errorf("Iterate did not visit %q", id)
+		}
+	}
+	if len(seen) != len(ids) {
+		t.Errorf("Iterate visited %d ids, want %d", len(seen), len(ids))
+	}
+}
@@ -0,0 +1,86 @@
+package trie
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWalkSkipSubtreeAndStopWalk(t *testing.T) {
+	tr := NewTrie()
+	for _, e := range []string{"apple", "app", "apply", "banana"} {
+		tr.Add(e)
+	}
+
+	var visited []string
+	if err := tr.Walk(func(entry string, count int64) error {
+		visited = append(visited, entry)
+		if entry == "app" {
+			return SkipSubtree
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk returned %v, want nil", err)
+	}
+	for _, skipped := range []string{"apple", "apply"} {
+		for _, v := range visited {
+			if v == skipped {
+				t.Errorf("Walk visited %q despite SkipSubtree at %q", skipped, "app")
+			}
+		}
+	}
+
+	visited = nil
+	if err := tr.Walk(func(entry string, count int64) error {
+		visited = append(visited, entry)
+		if entry == "app" {
+			return StopWalk
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk returned %v, want nil after StopWalk", err)
+	}
+	want := []string{"app"}
+	if len(visited) != len(want) || visited[0] != want[0] {
+		t.Fatalf("Walk after StopWalk visited %v, want %v", visited, want)
+	}
+
+	sentinel := errors.New("boom")
+	if err := tr.Walk(func(entry string, count int64) error {
+		return sentinel
+	}); err != sentinel {
+		t.Fatalf("Walk returned %v, want sentinel error %v", err, sentinel)
+	}
+}
+
+func TestWalkPrefix(t *testing.T) {
+	tr := NewTrie()
+	for _, e := range []string{"apple", "app", "apply", "banana"} {
+		tr.Add(e)
+	}
+
+	var got []string
+	if err := tr.WalkPrefix("app", func(entry string, count int64) error {
+		got = append(got, entry)
+		return nil
+	}); err != nil {
+		t.Fatalf("WalkPrefix returned %v, want nil", err)
+	}
+
+	want := map[string]bool{"app": true, "apple": true, "apply": true}
+	if len(got) != len(want) {
+		t.Fatalf("WalkPrefix(%q) = %v, want entries %v", "app", got, want)
+	}
+	for _, e := range got {
+		if !want[e] {
+			t.Errorf("WalkPrefix(%q) unexpectedly visited %q", "app", e)
+		}
+	}
+
+	got = nil
+	if err := tr.WalkPrefix("nope", func(entry string, count int64) error {
+		got = append(got, entry)
+		return nil
+	}); err != nil || len(got) != 0 {
+		t.Fatalf("WalkPrefix(%q) = %v, %v, want no entries and nil error", "nope", got, err)
+	}
+}